@@ -0,0 +1,37 @@
+package headers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jub0bs/cors/internal/headers"
+)
+
+// BenchmarkFirst verifies that First, used on preflight hot paths, performs
+// no heap allocations regardless of whether the header is present.
+func BenchmarkFirst(b *testing.B) {
+	hdrs := http.Header{headers.Origin: []string{"https://example.com"}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		headers.First(hdrs, headers.Origin)
+	}
+}
+
+func BenchmarkFirst_absent(b *testing.B) {
+	hdrs := http.Header{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		headers.First(hdrs, headers.Origin)
+	}
+}
+
+// BenchmarkAddVary_absent exercises AddVary's fast path (k absent from
+// hdrs), which must incur no heap allocation.
+func BenchmarkAddVary_absent(b *testing.B) {
+	sgl := headers.OriginSgl
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		hdrs := http.Header{}
+		headers.AddVary(hdrs, headers.Origin, sgl)
+	}
+}
@@ -0,0 +1,176 @@
+package radix_test
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/jub0bs/cors/internal/origin/radix"
+)
+
+func TestTreeDelete(t *testing.T) {
+	var tr radix.Tree
+	tr.Insert("foo.example.com", 1)
+	tr.Insert("bar.example.com", 2)
+	tr.Insert("baz.example.com", 3)
+
+	if !tr.Contains("bar.example.com", 2) {
+		t.Fatal("Contains(bar.example.com, 2) = false before Delete, want true")
+	}
+	if !tr.Delete("bar.example.com", 2) {
+		t.Error("Delete(bar.example.com, 2) = false, want true")
+	}
+	if tr.Contains("bar.example.com", 2) {
+		t.Error("Contains(bar.example.com, 2) = true after Delete, want false")
+	}
+	if tr.Delete("bar.example.com", 2) {
+		t.Error("second Delete(bar.example.com, 2) = true, want false (already removed)")
+	}
+	if tr.Delete("no-such-key.example.com", 42) {
+		t.Error("Delete of a key that was never inserted = true, want false")
+	}
+
+	// surviving pairs should still be reachable as before.
+	if !tr.Contains("foo.example.com", 1) {
+		t.Error("Contains(foo.example.com, 1) = false, want true")
+	}
+	if !tr.Contains("baz.example.com", 3) {
+		t.Error("Contains(baz.example.com, 3) = false, want true")
+	}
+
+	// the resulting tree should be shaped exactly as a fresh build of the
+	// surviving pairs would be.
+	var want radix.Tree
+	want.Insert("foo.example.com", 1)
+	want.Insert("baz.example.com", 3)
+	if !reflect.DeepEqual(tr, want) {
+		t.Errorf("tree shape after Delete differs from a fresh build:\ngot:  %#v\nwant: %#v", tr, want)
+	}
+}
+
+func TestTreeDeleteWildcard(t *testing.T) {
+	var tr radix.Tree
+	tr.Insert("*.example.com", 1)
+	tr.Insert("a.example.com", 2)
+
+	if !tr.Contains("x.example.com", 1) {
+		t.Fatal("expected wildcard match before Delete")
+	}
+	if !tr.Delete("*.example.com", 1) {
+		t.Error("Delete(*.example.com, 1) = false, want true")
+	}
+	if tr.Contains("x.example.com", 1) {
+		t.Error("expected no wildcard match after Delete")
+	}
+	if !tr.Contains("a.example.com", 2) {
+		t.Error("unrelated exact match should survive Delete of the wildcard entry")
+	}
+}
+
+func TestTreeDeleteCollapsesToRoot(t *testing.T) {
+	var tr radix.Tree
+	tr.Insert("example.com", 1)
+	if !tr.Delete("example.com", 1) {
+		t.Fatal("Delete(example.com, 1) = false, want true")
+	}
+	var want radix.Tree
+	if !reflect.DeepEqual(tr, want) {
+		t.Errorf("deleting the only entry should leave an empty tree; got %#v", tr)
+	}
+}
+
+// TestTreeDeleteInterleaved interleaves Insert and Delete calls across a
+// fixed pool of key-value pairs, checks after every Delete that its result
+// agrees with the expected membership, and checks once all rounds are done
+// that the tree's shape matches that of a tree freshly built from the
+// surviving pairs and that Contains reports every surviving pair.
+func TestTreeDeleteInterleaved(t *testing.T) {
+	keys := []string{
+		"a.example.com",
+		"b.example.com",
+		"c.example.com",
+		"a.b.example.net",
+		"x.internal",
+		"y.internal",
+		"*.example.org",
+	}
+	rng := rand.New(rand.NewSource(42))
+	var tr radix.Tree
+	present := make(map[[2]any]bool)
+	for round := 0; round < 2000; round++ {
+		k := keys[rng.Intn(len(keys))]
+		v := rng.Intn(4)
+		if rng.Intn(2) == 0 {
+			tr.Insert(k, v)
+			present[[2]any{k, v}] = true
+		} else {
+			got := tr.Delete(k, v)
+			want := present[[2]any{k, v}]
+			if got != want {
+				t.Fatalf("round %d: Delete(%q, %d) = %t, want %t", round, k, v, got, want)
+			}
+			delete(present, [2]any{k, v})
+		}
+	}
+	var want radix.Tree
+	for kv := range present {
+		want.Insert(kv[0].(string), kv[1].(int))
+	}
+	if !reflect.DeepEqual(tr, want) {
+		t.Fatal("tree shape diverged from a fresh build of the surviving pairs after interleaved Insert/Delete calls")
+	}
+	for kv := range present {
+		if !tr.Contains(kv[0].(string), kv[1].(int)) {
+			t.Errorf("Contains(%q, %d) = false, want true", kv[0], kv[1])
+		}
+	}
+}
+
+func TestTreeValues(t *testing.T) {
+	var tr radix.Tree
+	tr.Insert("a.example.com", 1)
+	tr.Insert("*.example.com", 2)
+	tr.InsertGlob("https://*.*.example.net", 3)
+
+	cases := []struct {
+		desc string
+		host string
+		want []int
+	}{
+		{
+			desc: "exact match plus wildcard along the path",
+			host: "a.example.com",
+			want: []int{1, 2},
+		}, {
+			desc: "wildcard-only match",
+			host: "z.example.com",
+			want: []int{2},
+		}, {
+			desc: "glob-only match",
+			host: "https://a.b.example.net",
+			want: []int{3},
+		}, {
+			desc: "no match",
+			host: "a.example.org",
+			want: []int{},
+		},
+	}
+	for _, tc := range cases {
+		f := func(t *testing.T) {
+			got := tr.Values(tc.host)
+			sortInts(got)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Values(%q) = %v; want %v", tc.host, got, tc.want)
+			}
+		}
+		t.Run(tc.desc, f)
+	}
+}
+
+func sortInts(s []int) {
+	for i := 1; i < len(s); i++ {
+		for j := i; j > 0 && s[j-1] > s[j]; j-- {
+			s[j-1], s[j] = s[j], s[j-1]
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package radix
+
+// A compiledGlob is a glob-style origin pattern compiled into a sequence
+// of segments, ready to be matched against a host by match.
+type compiledGlob struct {
+	pattern  string // the original, uncompiled pattern
+	segments []globSegment
+}
+
+// literalSuffix returns the longest literal (non-wildcard) byte run at the
+// end of g's pattern, or "" if the pattern ends with a wildcard segment.
+// It is used to key g in the radix tree so that Contains can prune
+// candidates before running g's NFA.
+func (g compiledGlob) literalSuffix() string {
+	if len(g.segments) == 0 {
+		return ""
+	}
+	last := g.segments[len(g.segments)-1]
+	if last.kind != segLiteral {
+		return ""
+	}
+	return last.lit
+}
+
+// match reports whether host satisfies g's pattern in full.
+func (g compiledGlob) match(host string) bool {
+	return matchSegments(g.segments, host)
+}
+
+type globSegmentKind uint8
+
+const (
+	segLiteral   globSegmentKind = iota // an exact run of bytes
+	segLabelStar                        // *  : any non-empty run of bytes within one DNS label
+	segMultiStar                        // ** : any run of bytes, possibly crossing labels
+	segAny                              // ?  : exactly one byte
+)
+
+type globSegment struct {
+	kind globSegmentKind
+	lit  string // populated only when kind == segLiteral
+}
+
+// compileGlob compiles pattern into a compiledGlob. Consecutive literal
+// bytes are coalesced into a single segLiteral segment; a run of two `*`
+// is compiled as a single segMultiStar segment, a lone `*` as a
+// segLabelStar segment, and `?` as a segAny segment.
+func compileGlob(pattern string) compiledGlob {
+	var segments []globSegment
+	var lit []byte
+	flushLit := func() {
+		if len(lit) > 0 {
+			segments = append(segments, globSegment{kind: segLiteral, lit: string(lit)})
+			lit = lit[:0]
+		}
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*':
+			flushLit()
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				segments = append(segments, globSegment{kind: segMultiStar})
+				i++
+				continue
+			}
+			segments = append(segments, globSegment{kind: segLabelStar})
+		case '?':
+			flushLit()
+			segments = append(segments, globSegment{kind: segAny})
+		default:
+			lit = append(lit, pattern[i])
+		}
+	}
+	flushLit()
+	return compiledGlob{pattern: pattern, segments: segments}
+}
+
+// matchSegments reports whether host is matched in full by segs.
+// It simulates, bottom-up via dynamic programming, the Thompson-style NFA
+// that segs conceptually describes: next[j] tracks whether the segments
+// processed so far match host[j:], for every position j at once, so that
+// segLabelStar and segMultiStar are resolved by folding over next with a
+// running OR rather than by backtracking into recursive trial matches.
+// This bounds the cost of a single match to O(len(segs)*len(host)) time
+// and O(len(host)) space, regardless of how the stars in segs are
+// arranged, which matters because host is derived from a request's Origin
+// header and is therefore attacker-controlled.
+func matchSegments(segs []globSegment, host string) bool {
+	n := len(host)
+	next := make([]bool, n+1)
+	cur := make([]bool, n+1)
+	next[n] = true // no segments left: matches only the empty remainder
+
+	for i := len(segs) - 1; i >= 0; i-- {
+		seg := segs[i]
+		for j := range cur {
+			cur[j] = false
+		}
+		switch seg.kind {
+		case segLiteral:
+			lit := seg.lit
+			for j := 0; j+len(lit) <= n; j++ {
+				cur[j] = host[j:j+len(lit)] == lit && next[j+len(lit)]
+			}
+		case segAny:
+			for j := 0; j < n; j++ {
+				cur[j] = next[j+1]
+			}
+		case segMultiStar:
+			// cur[j] = OR of next[j:], folded right to left.
+			acc := next[n]
+			cur[n] = acc
+			for j := n - 1; j >= 0; j-- {
+				acc = acc || next[j]
+				cur[j] = acc
+			}
+		case segLabelStar:
+			// cur[j] = OR of next[j+1:labelEnd], where labelEnd is the
+			// next '.' at or after j (or len(host)); acc resets at each
+			// '.' so that the star never crosses a label boundary, and
+			// cur[n] is left false since no non-empty match starts there.
+			var acc bool
+			for j := n - 1; j >= 0; j-- {
+				if host[j] == '.' {
+					acc = false
+				} else {
+					acc = acc || next[j+1]
+				}
+				cur[j] = acc
+			}
+		}
+		next, cur = cur, next
+	}
+	return next[0]
+}
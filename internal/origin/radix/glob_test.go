@@ -0,0 +1,179 @@
+package radix_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jub0bs/cors/internal/origin/radix"
+)
+
+func TestTreeInsertGlob(t *testing.T) {
+	const (
+		vSubSub = iota
+		vApi
+		vIPv6
+	)
+	var tr radix.Tree
+	tr.InsertGlob("https://*.*.example.com", vSubSub)
+	tr.InsertGlob("https://api-**.internal", vApi)
+	tr.InsertGlob("https://[?:?:?:?:?:?:?:?]", vIPv6)
+
+	cases := []struct {
+		desc string
+		host string
+		v    int
+		want bool
+	}{
+		{
+			desc: "single label-star per dot-separated label, matches",
+			host: "https://a.b.example.com",
+			v:    vSubSub,
+			want: true,
+		}, {
+			desc: "label-star must not swallow a label boundary: too few labels",
+			host: "https://a.example.com",
+			v:    vSubSub,
+			want: false,
+		}, {
+			desc: "label-star must not swallow a label boundary: too many labels",
+			host: "https://a.b.c.example.com",
+			v:    vSubSub,
+			want: false,
+		}, {
+			desc: "label-star requires a non-empty label",
+			host: "https://.b.example.com",
+			v:    vSubSub,
+			want: false,
+		}, {
+			desc: "multi-label star crosses labels",
+			host: "https://api-foo.bar.internal",
+			v:    vApi,
+			want: true,
+		}, {
+			desc: "multi-label star may match zero bytes",
+			host: "https://api-.internal",
+			v:    vApi,
+			want: true,
+		}, {
+			desc: "multi-label star does not excuse a mismatch in the literal before it",
+			host: "https://api.internal",
+			v:    vApi,
+			want: false,
+		}, {
+			desc: "? matches a single byte, including inside IPv6 bracket literals",
+			host: "https://[1:2:3:4:5:6:7:8]",
+			v:    vIPv6,
+			want: true,
+		}, {
+			desc: "? never matches more than one byte",
+			host: "https://[2001:db8::1]",
+			v:    vIPv6,
+			want: false,
+		}, {
+			desc: "a glob pattern never matches an unrelated value",
+			host: "https://a.b.example.com",
+			v:    vApi,
+			want: false,
+		},
+	}
+	for _, tc := range cases {
+		f := func(t *testing.T) {
+			got := tr.Contains(tc.host, tc.v)
+			if got != tc.want {
+				const tmpl = "Contains(%q, %d): got %t; want %t"
+				t.Errorf(tmpl, tc.host, tc.v, got, tc.want)
+			}
+		}
+		t.Run(tc.desc, f)
+	}
+}
+
+func TestTreeInsertGlobWildcardElem(t *testing.T) {
+	var tr radix.Tree
+	tr.InsertGlob("https://*.example.com", radix.WildcardElem)
+
+	cases := []struct {
+		host string
+		v    int
+		want bool
+	}{
+		{"https://a.example.com", 42, true},
+		{"https://a.example.com", radix.WildcardElem, true},
+		{"https://a.b.example.com", 42, false},
+	}
+	for _, tc := range cases {
+		got := tr.Contains(tc.host, tc.v)
+		if got != tc.want {
+			const tmpl = "Contains(%q, %d): got %t; want %t"
+			t.Errorf(tmpl, tc.host, tc.v, got, tc.want)
+		}
+	}
+}
+
+// TestTreeInsertGlobPathological guards against catastrophic backtracking
+// on patterns shaped like a*a*a*...a*b, which is attacker-reachable since
+// Contains is evaluated against the Origin header of incoming requests.
+// A naive backtracking matcher takes exponential time on such patterns; a
+// bounded-time matcher completes this (and the benchmark below) near-
+// instantly regardless of n.
+func TestTreeInsertGlobPathological(t *testing.T) {
+	const n = 30
+	pattern := "https://" + strings.Repeat("a**", n) + "b.example.com"
+	var tr radix.Tree
+	tr.InsertGlob(pattern, 1)
+
+	// almost matches: shares the literal suffix "b.example.com", so the
+	// tree cannot prune it and the matcher runs, but holds one "a" fewer
+	// than the n that the pattern requires.
+	almostMatch := "https://" + strings.Repeat("a", n-1) + "b.example.com"
+	if tr.Contains(almostMatch, 1) {
+		t.Errorf("Contains(%q, 1) = true, want false", almostMatch)
+	}
+
+	match := "https://" + strings.Repeat("a", n) + "b.example.com"
+	if !tr.Contains(match, 1) {
+		t.Errorf("Contains(%q, 1) = false, want true", match)
+	}
+}
+
+// BenchmarkTreeContainsGlob_pathological mirrors the adversarial-input
+// benchmarks added for SortedSet.Subsumes: it exercises the same a*a*a*...b
+// pattern shape against an almost-matching host, on which a backtracking
+// matcher would take exponential time, and should show cost growing at
+// most quadratically with n (n segments matched against O(n) bytes).
+func BenchmarkTreeContainsGlob_pathological(b *testing.B) {
+	const n = 30
+	pattern := "https://" + strings.Repeat("a**", n) + "b.example.com"
+	var tr radix.Tree
+	tr.InsertGlob(pattern, 1)
+	host := "https://" + strings.Repeat("a", n-1) + "b.example.com"
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tr.Contains(host, 1)
+	}
+}
+
+func TestTreeInsertGlobCoexistsWithInsert(t *testing.T) {
+	var tr radix.Tree
+	tr.Insert("*.example.com", 1)
+	tr.InsertGlob("https://*.*.example.net", 2)
+
+	cases := []struct {
+		host string
+		v    int
+		want bool
+	}{
+		{"a.example.com", 1, true},
+		{"https://a.b.example.net", 2, true},
+		{"https://a.b.example.net", 1, false},
+		{"a.example.com", 2, false},
+	}
+	for _, tc := range cases {
+		got := tr.Contains(tc.host, tc.v)
+		if got != tc.want {
+			const tmpl = "Contains(%q, %d): got %t; want %t"
+			t.Errorf(tmpl, tc.host, tc.v, got, tc.want)
+		}
+	}
+}
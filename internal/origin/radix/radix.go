@@ -1,6 +1,16 @@
 // Package radix provides an implementation of a specialized radix tree.
 // The implementation draws heavy inspiration from
 // https://github.com/armon/go-radix.
+//
+// # Concurrency
+//
+// A Tree is not safe for concurrent use by multiple goroutines if at
+// least one of them mutates the tree (via [Tree.Insert], [Tree.InsertGlob],
+// or [Tree.Delete]). Callers that need to add or remove origins at
+// runtime (e.g. in a multi-tenant setting) must serialize their own
+// writes, for instance with a [sync.Mutex] or [sync.RWMutex] guarding the
+// Tree; concurrent reads (via [Tree.Contains] or [Tree.Values]) that are
+// not interleaved with a write need no such guard.
 package radix
 
 import (
@@ -73,11 +83,219 @@ func (t *Tree) Insert(keyPattern string, v int) {
 		grandChild := &node{suffix: search}
 		grandChild.add(v, wildcardPattern)
 		child.insertEdge(lastByteIn(search), grandChild)
+		return
+	}
+}
+
+// InsertGlob inserts v in t under a glob-style origin pattern, in the
+// spirit of [gobwas/glob]: a single `*` matches any non-empty run of bytes
+// within one DNS label (i.e. it does not cross a `.`), `**` matches any
+// run of bytes regardless of label boundaries, and `?` matches exactly one
+// byte. Unlike Insert, stars need not be confined to a single leading
+// position.
+//
+// Internally, pattern is compiled once into a small Thompson-style NFA
+// (see matchSegments) and stored, keyed by the longest literal suffix of
+// pattern, in the same radix structure used by Insert; that suffix lets
+// Contains prune most hosts before ever running the NFA, and the NFA
+// itself is simulated by dynamic programming rather than backtracking, so
+// matching a host against it costs O(len(pattern)*len(host)) time
+// regardless of how pattern's stars are arranged. Insert's own semantics
+// and complexity are unaffected by InsertGlob.
+//
+// [gobwas/glob]: https://github.com/gobwas/glob
+func (t *Tree) InsertGlob(pattern string, v int) {
+	g := compileGlob(pattern)
+	n := t.descendLiteral(g.literalSuffix())
+	for i := range n.globs {
+		if n.globs[i].pattern == pattern {
+			addToSet(&n.globs[i].set, v)
+			return
+		}
+	}
+	var set util.Set[int]
+	addToSet(&set, v)
+	n.globs = append(n.globs, globEntry{pattern: pattern, glob: g, set: set})
+}
+
+// descendLiteral walks t along the right-to-left byte path given by
+// suffix, creating nodes as needed exactly as Insert does, and returns the
+// node reached. Unlike Insert, it records no value at that node; callers
+// do that themselves.
+func (t *Tree) descendLiteral(suffix string) *node {
+	var parent *node
+	n := &t.root
+	search := suffix
+	for {
+		if len(search) == 0 {
+			return n
+		}
+		parent = n
+		n = n.edges[lastByteIn(search)]
+		if n == nil { // no matching edge found; create one
+			child := &node{suffix: search}
+			parent.insertEdge(lastByteIn(search), child)
+			return child
+		}
+
+		// matching edge found
+		suffixLen := lengthOfCommonSuffix(search, n.suffix)
+		if suffixLen == len(n.suffix) { // n.suffix is a suffix of search
+			search, _ = splitRight(search, suffixLen)
+			continue
+		}
+
+		// n.suffix is NOT a suffix of search; split the node
+		child := new(node)
+		_, child.suffix = splitRight(search, suffixLen)
+		parent.insertEdge(lastByteIn(search), child)
+
+		// restore the existing node
+		byteBeforeSuffix := n.suffix[len(n.suffix)-1-suffixLen]
+		child.insertEdge(byteBeforeSuffix, n)
+		if len(search) == suffixLen { // search is a suffix of n.suffix
+			n.suffix, _ = splitRight(n.suffix, suffixLen)
+			return child
+		}
+		// search is NOT a suffix of n.suffix
+		n.suffix, _ = splitRight(n.suffix, suffixLen)
+		search, _ = splitRight(search, suffixLen)
+		grandChild := &node{suffix: search}
+		child.insertEdge(lastByteIn(search), grandChild)
+		return grandChild
+	}
+}
+
+// Delete removes v from the key-value pairs reachable via keyPattern
+// (interpreted exactly as in Insert, including its leading-*-as-wildcard
+// convention) and reports whether (keyPattern, v) was present beforehand.
+// Unlike Insert, Delete never creates nodes; it also collapses the tree
+// behind it so that its shape stays identical to what a fresh sequence of
+// Insert calls for the surviving pairs would have produced: a node left
+// with no values, no glob entries, and no edges is unlinked from its
+// parent, and a node left with no values or glob entries of its own and
+// exactly one remaining edge is merged into that child, by concatenating
+// child.suffix + n.suffix (suffixes are stored right to left, so the
+// child, being farther from the root, holds the more prefix-ward bytes).
+func (t *Tree) Delete(keyPattern string, v int) bool {
+	var wildcardPattern bool
+	if strings.HasPrefix(keyPattern, "*") {
+		wildcardPattern = true
+		keyPattern = keyPattern[1:]
+	}
+	type step struct {
+		parent *node
+		label  byte
+		node   *node
+	}
+	var path []step
+	n := &t.root
+	search := keyPattern
+	for len(search) > 0 {
+		label := lastByteIn(search)
+		child := n.edges[label]
+		if child == nil || !strings.HasSuffix(search, child.suffix) {
+			return false
+		}
+		search, _ = splitRight(search, len(child.suffix))
+		path = append(path, step{parent: n, label: label, node: child})
+		n = child
+	}
+	set := &n.set
+	if wildcardPattern {
+		set = &n.wildcardSet
+	}
+	if v == WildcardElem {
+		// *set may be the shared wildcardSingleton; drop it, don't mutate it.
+		if !set.Contains(WildcardElem) {
+			return false
+		}
+		*set = nil
+	} else if !set.Delete(v) {
+		return false
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		p, label, cur := path[i].parent, path[i].label, path[i].node
+		if cur.empty() {
+			delete(p.edges, label)
+			if len(p.edges) == 0 {
+				p.edges = nil
+			}
+			continue // cur is gone; p itself may now be collapsible
+		}
+		if len(cur.edges) == 1 && cur.set.Len() == 0 && cur.wildcardSet.Len() == 0 && len(cur.globs) == 0 {
+			var child *node
+			for _, c := range cur.edges {
+				child = c
+			}
+			child.suffix += cur.suffix
+			p.edges[label] = child
+		}
+		break // cur's edge count into p is unchanged; ancestors are unaffected
+	}
+	return true
+}
+
+// empty reports whether n holds no values, no glob entries, and no edges,
+// i.e. whether n is safe to unlink from its parent.
+func (n *node) empty() bool {
+	return n.set.Len() == 0 &&
+		n.wildcardSet.Len() == 0 &&
+		len(n.globs) == 0 &&
+		len(n.edges) == 0
+}
+
+// Values returns, without duplicates and in no particular order, every
+// value v such that t.Contains(k, v) holds: those found via an exact
+// match, via a wildcard pattern along the path to k, and via any glob
+// pattern (see InsertGlob) that matches k.
+func (t *Tree) Values(k string) []int {
+	found := make(map[int]struct{})
+	collect := func(set util.Set[int]) {
+		for _, v := range set.Elems() {
+			found[v] = struct{}{}
+		}
+	}
+	n := &t.root
+	search := k
+	for {
+		for i := range n.globs {
+			if n.globs[i].glob.match(k) {
+				collect(n.globs[i].set)
+			}
+		}
+		if len(search) == 0 {
+			collect(n.set)
+			break
+		}
+		// as in Contains, a wildcard edge only matches a non-empty remainder
+		collect(n.wildcardSet)
+		child := n.edges[lastByteIn(search)]
+		if child == nil || !strings.HasSuffix(search, child.suffix) {
+			break
+		}
+		search, _ = splitRight(search, len(child.suffix))
+		n = child
+	}
+	values := make([]int, 0, len(found))
+	for v := range found {
+		values = append(values, v)
 	}
+	return values
 }
 
 // Contains reports whether t contains key-value pair (k,v).
+// Glob-style patterns inserted via InsertGlob are only consulted if the
+// plain (literal or single-leading-wildcard) lookup fails; see
+// [Tree.InsertGlob] for the associated cost.
 func (t *Tree) Contains(k string, v int) bool {
+	if t.containsLiteral(k, v) {
+		return true
+	}
+	return t.containsGlob(k, v)
+}
+
+func (t *Tree) containsLiteral(k string, v int) bool {
 	n := &t.root
 	search := k
 	for {
@@ -105,6 +323,44 @@ func (t *Tree) Contains(k string, v int) bool {
 	}
 }
 
+// containsGlob reports whether some pattern inserted via InsertGlob matches
+// k with value v (or WildcardElem). It walks the radix tree along the
+// literal suffixes of k, in the same right-to-left fashion as
+// containsLiteral, to gather the candidate glob entries that share a
+// suffix with k, then runs each candidate's NFA (see matchSegments, which
+// simulates it by dynamic programming in O(len(pattern)*len(k)) time,
+// not by backtracking) against the whole of k. This keeps the cost of
+// glob matching proportional to c·len(pattern)·len(k), where c is the
+// number of candidates sharing a literal suffix with k, rather
+// than to the total number of glob patterns ever inserted, and bounded
+// even when k is derived from an attacker-controlled Origin header.
+func (t *Tree) containsGlob(k string, v int) bool {
+	n := &t.root
+	search := k
+	for {
+		for i := range n.globs {
+			e := &n.globs[i]
+			if !e.set.Contains(v) && !e.set.Contains(WildcardElem) {
+				continue
+			}
+			if e.glob.match(k) {
+				return true
+			}
+		}
+		if len(search) == 0 {
+			return false
+		}
+		n = n.edges[lastByteIn(search)]
+		if n == nil {
+			return false
+		}
+		if !strings.HasSuffix(search, n.suffix) {
+			return false
+		}
+		search, _ = splitRight(search, len(n.suffix))
+	}
+}
+
 // assumes s is non-empty
 func lastByteIn(str string) byte {
 	return str[len(str)-1]
@@ -132,15 +388,30 @@ type node struct {
 	// values in the "conceptual" child node down the wildcard edge
 	// that stems from this node
 	wildcardSet util.Set[int]
+	// glob-style patterns (inserted via InsertGlob) whose longest
+	// literal suffix is the suffix formed by this node and its ancestors
+	globs []globEntry
+}
+
+// A globEntry associates a compiled glob pattern with the values for which
+// it was inserted via InsertGlob.
+type globEntry struct {
+	pattern string // the original, uncompiled pattern; used to dedupe inserts
+	glob    compiledGlob
+	set     util.Set[int]
 }
 
 func (n *node) add(elem int, wildcardPattern bool) {
-	var set *util.Set[int]
+	set := &n.set
 	if wildcardPattern {
 		set = &n.wildcardSet
-	} else {
-		set = &n.set
 	}
+	addToSet(set, elem)
+}
+
+// addToSet adds elem to *set, honoring the sentinel WildcardElem and
+// lazily allocating *set as necessary.
+func addToSet(set *util.Set[int], elem int) {
 	if elem == WildcardElem {
 		*set = wildcardSingleton
 		return
@@ -48,38 +48,73 @@ func (set SortedSet) String() string {
 	return strings.Join(elems, ",")
 }
 
-// Subsumes reports whether csv is a sequence of comma-separated names that are
+// Subsumes reports whether csvs, once conceptually concatenated with commas
+// (in order), forms a sequence of comma-separated names that are
 //   - all elements of set,
 //   - sorted in lexicographically order,
 //   - unique.
-func (set SortedSet) Subsumes(csv string) bool {
-	if csv == "" {
-		return true
-	}
+//
+// Subsumes accepts a slice, rather than a single string, to accommodate
+// for user agents or intermediaries that split a single
+// Access-Control-Request-Headers field into several fields of the same name;
+// per the Fetch standard, only one such field is ever sent, but Subsumes
+// tolerates the split regardless.
+// A nil or empty csvs, as well as any empty elements therein, are deemed
+// subsumed.
+func (set SortedSet) Subsumes(csvs []string) bool {
 	posOfLastNameSeen := -1
 	chunkSize := set.maxLen + 1 // to accommodate for at least one comma
-	for {
-		// As a defense against maliciously long names in csv,
-		// we process at most chunkSize of csv's leading bytes per iteration.
-		name, rest, commaFound := cutAtComma(csv, chunkSize)
-		pos, ok := set.m[name]
-		if !ok {
-			return false
-		}
-		// The names in csv are expected to be sorted in lexicographical order
-		// and appear at most once in csv.
-		// Therefore, the positions (in set) of the names that
-		// appear in csv should form a strictly increasing sequence.
-		// If that's not actually the case, bail out.
-		if pos <= posOfLastNameSeen {
-			return false
+	for _, csv := range csvs {
+		for csv != "" {
+			// As a defense against maliciously long names in csv,
+			// we process at most chunkSize of csv's leading bytes per
+			// iteration.
+			name, rest, commaFound := cutAtComma(csv, chunkSize)
+			pos, ok := set.m[name]
+			if !ok {
+				return false
+			}
+			// The names in csvs are expected to be sorted in lexicographical
+			// order and appear at most once across the whole of csvs.
+			// Therefore, the positions (in set) of the names that
+			// appear in csvs should form a strictly increasing sequence.
+			// If that's not actually the case, bail out.
+			if pos <= posOfLastNameSeen {
+				return false
+			}
+			posOfLastNameSeen = pos
+			if !commaFound { // We have now exhausted the names in csv.
+				break
+			}
+			csv = rest
 		}
-		posOfLastNameSeen = pos
-		if !commaFound { // We have now exhausted the names in csv.
-			return true
+	}
+	return true
+}
+
+// Missing reports, in a single walk over csvs using the same chunked
+// cutAtComma scan as Subsumes, every name that csvs requests (split on
+// commas, in order, across all of csvs) and the subset of those names
+// that are not elements of set. Unlike Subsumes, Missing does not check
+// ordering or uniqueness; it exists to power diagnostics that must report
+// which requested names were disallowed, not merely whether the request
+// as a whole should be.
+func (set SortedSet) Missing(csvs []string) (requested, missing []string) {
+	chunkSize := set.maxLen + 1 // to accommodate for at least one comma
+	for _, csv := range csvs {
+		for csv != "" {
+			name, rest, commaFound := cutAtComma(csv, chunkSize)
+			requested = append(requested, name)
+			if _, ok := set.m[name]; !ok {
+				missing = append(missing, name)
+			}
+			if !commaFound {
+				break
+			}
+			csv = rest
 		}
-		csv = rest
 	}
+	return requested, missing
 }
 
 // cutAtComma slices s around the first comma that appears among (up to) the
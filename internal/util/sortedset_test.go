@@ -2,6 +2,7 @@ package util_test
 
 import (
 	"slices"
+	"strings"
 	"testing"
 
 	"github.com/jub0bs/cors/internal/util"
@@ -93,13 +94,13 @@ func TestSortedSet(t *testing.T) {
 				t.Errorf(tmpl, elems, combined, tc.combined)
 			}
 			for _, sub := range tc.subsets {
-				if !s.Subsumes(sub) {
+				if !s.Subsumes([]string{sub}) {
 					const tmpl = "%q is not a subset of %q, but should be"
 					t.Errorf(tmpl, sub, s)
 				}
 			}
 			for _, notSub := range tc.notSubsets {
-				if s.Subsumes(notSub) {
+				if s.Subsumes([]string{notSub}) {
 					const tmpl = "%q is a subset of %q, but should not be"
 					t.Errorf(tmpl, notSub, s)
 				}
@@ -108,3 +109,133 @@ func TestSortedSet(t *testing.T) {
 		t.Run(tc.desc, f)
 	}
 }
+
+func TestSortedSetSubsumesSplitAcrossElems(t *testing.T) {
+	s := util.NewSortedSet("bar", "baz", "foo", "qux")
+	cases := []struct {
+		desc string
+		csvs []string
+		want bool
+	}{
+		{
+			desc: "nil slice",
+			csvs: nil,
+			want: true,
+		}, {
+			desc: "empty slice",
+			csvs: []string{},
+			want: true,
+		}, {
+			desc: "empty elems",
+			csvs: []string{"", ""},
+			want: true,
+		}, {
+			desc: "one elem per name, in order",
+			csvs: []string{"bar", "baz", "foo"},
+			want: true,
+		}, {
+			desc: "mixture of single- and multi-name elems",
+			csvs: []string{"bar,baz", "", "foo,qux"},
+			want: true,
+		}, {
+			desc: "order violated across elems",
+			csvs: []string{"foo", "bar"},
+			want: false,
+		}, {
+			desc: "duplicate across elems",
+			csvs: []string{"bar", "bar"},
+			want: false,
+		}, {
+			desc: "unknown name in later elem",
+			csvs: []string{"bar", "qux,quux"},
+			want: false,
+		},
+	}
+	for _, tc := range cases {
+		f := func(t *testing.T) {
+			got := s.Subsumes(tc.csvs)
+			if got != tc.want {
+				const tmpl = "Subsumes(%#v): got %t; want %t"
+				t.Errorf(tmpl, tc.csvs, got, tc.want)
+			}
+		}
+		t.Run(tc.desc, f)
+	}
+}
+
+func TestSortedSetMissing(t *testing.T) {
+	s := util.NewSortedSet("bar", "baz", "foo")
+	cases := []struct {
+		desc          string
+		csvs          []string
+		wantRequested []string
+		wantMissing   []string
+	}{
+		{
+			desc: "nil slice",
+		}, {
+			desc:          "all present",
+			csvs:          []string{"bar,foo"},
+			wantRequested: []string{"bar", "foo"},
+		}, {
+			desc:          "some missing",
+			csvs:          []string{"bar,qux,foo"},
+			wantRequested: []string{"bar", "qux", "foo"},
+			wantMissing:   []string{"qux"},
+		}, {
+			desc:          "split across elems",
+			csvs:          []string{"qux", "baz,quux"},
+			wantRequested: []string{"qux", "baz", "quux"},
+			wantMissing:   []string{"qux", "quux"},
+		},
+	}
+	for _, tc := range cases {
+		f := func(t *testing.T) {
+			requested, missing := s.Missing(tc.csvs)
+			if !slices.Equal(requested, tc.wantRequested) {
+				const tmpl = "Missing(%#v) requested: got %#v; want %#v"
+				t.Errorf(tmpl, tc.csvs, requested, tc.wantRequested)
+			}
+			if !slices.Equal(missing, tc.wantMissing) {
+				const tmpl = "Missing(%#v) missing: got %#v; want %#v"
+				t.Errorf(tmpl, tc.csvs, missing, tc.wantMissing)
+			}
+		}
+		t.Run(tc.desc, f)
+	}
+}
+
+// adversarialACRH mimics an Access-Control-Request-Headers field value
+// crafted to probe Subsumes for quadratic behavior or pathological
+// allocations: a thousand commas and no actual header names.
+var adversarialACRH = strings.Repeat(",", 1024)
+
+func BenchmarkSortedSet_Subsumes_adversarialSingleValue(b *testing.B) {
+	s := util.NewSortedSet("bar", "baz", "foo")
+	csvs := []string{adversarialACRH}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s.Subsumes(csvs)
+	}
+}
+
+func BenchmarkSortedSet_Subsumes_adversarialSplitValues(b *testing.B) {
+	s := util.NewSortedSet("bar", "baz", "foo")
+	csvs := make([]string, 1024)
+	for i := range csvs {
+		csvs[i] = ","
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s.Subsumes(csvs)
+	}
+}
+
+func BenchmarkSortedSet_Subsumes_typical(b *testing.B) {
+	s := util.NewSortedSet("authorization", "content-type", "x-requested-with")
+	csvs := []string{"authorization,content-type,x-requested-with"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s.Subsumes(csvs)
+	}
+}
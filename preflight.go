@@ -0,0 +1,121 @@
+package cors
+
+import (
+	"net/http"
+
+	"github.com/jub0bs/cors/internal/headers"
+	"github.com/jub0bs/cors/internal/origin/radix"
+	"github.com/jub0bs/cors/internal/util"
+)
+
+// originAllowed is the sentinel value under which every origin accepted
+// by a Config is keyed in Origins; origins carry no additional metadata
+// in this package yet.
+const originAllowed = 0
+
+// A Config describes which preflight requests a middleware built on top
+// of it should accept. The zero value of a Config accepts nothing.
+type Config struct {
+	// Origins matches a request's Origin header, including glob-style
+	// patterns (see [radix.Tree.InsertGlob]).
+	Origins radix.Tree
+
+	// Methods is the set of methods allowed in
+	// Access-Control-Request-Method.
+	Methods util.SortedSet
+
+	// Headers is the set of header names allowed in
+	// Access-Control-Request-Headers.
+	Headers util.SortedSet
+
+	// PrivateNetwork reports whether Access-Control-Request-Private-Network
+	// is granted.
+	PrivateNetwork bool
+
+	// Trace, if non-nil, is notified of HandlePreflight's decisions for
+	// requests whose context carries no [MiddlewareTrace] of its own.
+	// A trace installed via [WithTrace] on r's context takes precedence
+	// over Trace, which is then not notified at all; unlike nested
+	// WithTrace calls, the two are not composed.
+	Trace *MiddlewareTrace
+}
+
+// AddOrigin registers pattern (an exact origin or a glob-style pattern,
+// see [radix.Tree.InsertGlob]) as allowed under c.
+func (c *Config) AddOrigin(pattern string) {
+	c.Origins.InsertGlob(pattern, originAllowed)
+}
+
+// HandlePreflight reports whether r, a CORS preflight request, is
+// accepted under c:
+//   - r's Origin header must match c.Origins;
+//   - the method named in r's Access-Control-Request-Method header must
+//     be an element of c.Methods;
+//   - every header name listed across all of r.Header's
+//     Access-Control-Request-Headers values must be an element of
+//     c.Headers (see [util.SortedSet.Subsumes], which is passed
+//     r.Header[...] directly so that user agents or intermediaries that
+//     split that header across several fields of the same name are
+//     tolerated);
+//   - if r carries an Access-Control-Request-Private-Network header,
+//     c.PrivateNetwork must be true.
+//
+// Whichever [MiddlewareTrace] is in effect for r (see c.Trace and
+// [WithTrace]) is notified of the specific decision reached, whether
+// rejection at the first failing step or full acceptance.
+func (c *Config) HandlePreflight(r *http.Request) bool {
+	trace := ContextMiddlewareTrace(r.Context())
+	if trace == nil {
+		trace = c.Trace
+	}
+
+	origin, _, ok := headers.First(r.Header, headers.Origin)
+	if !ok || !c.Origins.Contains(origin, originAllowed) {
+		if trace != nil && trace.OriginRejected != nil {
+			trace.OriginRejected(origin)
+		}
+		return false
+	}
+
+	method, _, ok := headers.First(r.Header, headers.ACRM)
+	if !ok || !c.Methods.Subsumes([]string{method}) {
+		if trace != nil && trace.MethodRejected != nil {
+			trace.MethodRejected(method)
+		}
+		return false
+	}
+
+	acrh := r.Header[headers.ACRH]
+	if !c.Headers.Subsumes(acrh) {
+		if trace != nil && trace.HeadersRejected != nil {
+			requested, missing := c.Headers.Missing(acrh)
+			trace.HeadersRejected(requested, missing)
+		}
+		return false
+	}
+
+	pnRequested := false
+	if v, _, ok := headers.First(r.Header, headers.ACRPN); ok && v == headers.ValueTrue {
+		pnRequested = true
+		if !c.PrivateNetwork {
+			if trace != nil && trace.PrivateNetworkRejected != nil {
+				trace.PrivateNetworkRejected()
+			}
+			return false
+		}
+	}
+
+	if trace != nil && trace.PreflightAccepted != nil {
+		var grantedHeaders []string
+		if len(acrh) > 0 {
+			grantedHeaders, _ = c.Headers.Missing(acrh)
+		}
+		trace.PreflightAccepted(&PreflightInfo{
+			Origin:         origin,
+			Method:         method,
+			Headers:        grantedHeaders,
+			PrivateNetwork: pnRequested,
+		})
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package cors_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jub0bs/cors"
+	"github.com/jub0bs/cors/internal/headers"
+	"github.com/jub0bs/cors/internal/util"
+)
+
+func newTestConfig() *cors.Config {
+	var cfg cors.Config
+	cfg.AddOrigin("https://example.com")
+	cfg.Methods = util.NewSortedSet("GET", "POST")
+	cfg.Headers = util.NewSortedSet("content-type", "x-foo")
+	return &cfg
+}
+
+func TestConfigHandlePreflight(t *testing.T) {
+	cases := []struct {
+		desc   string
+		header http.Header
+		want   bool
+	}{
+		{
+			desc: "no ACRH: accepted",
+			header: http.Header{
+				headers.Origin: {"https://example.com"},
+				headers.ACRM:   {"GET"},
+			},
+			want: true,
+		}, {
+			desc: "single ACRH value: accepted",
+			header: http.Header{
+				headers.Origin: {"https://example.com"},
+				headers.ACRM:   {"GET"},
+				headers.ACRH:   {"content-type,x-foo"},
+			},
+			want: true,
+		}, {
+			desc: "ACRH split across several fields of the same name: accepted",
+			header: http.Header{
+				headers.Origin: {"https://example.com"},
+				headers.ACRM:   {"GET"},
+				headers.ACRH:   {"content-type", "x-foo"},
+			},
+			want: true,
+		}, {
+			desc: "origin not allowed",
+			header: http.Header{
+				headers.Origin: {"https://evil.example.com"},
+				headers.ACRM:   {"GET"},
+			},
+			want: false,
+		}, {
+			desc: "Origin header absent",
+			header: http.Header{
+				headers.ACRM: {"GET"},
+			},
+			want: false,
+		}, {
+			desc: "method not allowed",
+			header: http.Header{
+				headers.Origin: {"https://example.com"},
+				headers.ACRM:   {"DELETE"},
+			},
+			want: false,
+		}, {
+			desc: "header name not allowed",
+			header: http.Header{
+				headers.Origin: {"https://example.com"},
+				headers.ACRM:   {"GET"},
+				headers.ACRH:   {"content-type,x-bar"},
+			},
+			want: false,
+		},
+	}
+	cfg := newTestConfig()
+	for _, tc := range cases {
+		f := func(t *testing.T) {
+			req := &http.Request{Header: tc.header}
+			if got := cfg.HandlePreflight(req); got != tc.want {
+				t.Errorf("HandlePreflight(%v) = %t, want %t", tc.header, got, tc.want)
+			}
+		}
+		t.Run(tc.desc, f)
+	}
+}
+
+// BenchmarkConfigHandlePreflight_adversarialHeaders exercises
+// Config.HandlePreflight, the actual production call site for
+// util.SortedSet.Subsumes, against an adversarial
+// Access-Control-Request-Headers value (mirroring internal/util's own
+// adversarial Subsumes benchmarks) to confirm the real preflight path,
+// not just the library function in isolation, stays allocation-light.
+func BenchmarkConfigHandlePreflight_adversarialHeaders(b *testing.B) {
+	cfg := newTestConfig()
+	req := &http.Request{Header: http.Header{
+		headers.Origin: {"https://example.com"},
+		headers.ACRM:   {"GET"},
+		headers.ACRH:   {strings.Repeat(",", 1024)},
+	}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cfg.HandlePreflight(req)
+	}
+}
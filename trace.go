@@ -0,0 +1,173 @@
+package cors
+
+import "context"
+
+// A PreflightInfo carries the diagnostic payload passed to
+// [MiddlewareTrace.PreflightAccepted] once a preflight request has cleared
+// every one of the middleware's checks.
+type PreflightInfo struct {
+	// Origin is the value of the request's Origin header.
+	Origin string
+
+	// Method is the value of the request's Access-Control-Request-Method
+	// header.
+	Method string
+
+	// Headers holds the header names granted in response to the
+	// request's Access-Control-Request-Headers header, sorted in
+	// lexicographical order; it is nil if that header was absent.
+	Headers []string
+
+	// PrivateNetwork reports whether the request's
+	// Access-Control-Request-Private-Network header was present and
+	// access to the private network was granted.
+	PrivateNetwork bool
+}
+
+// A MiddlewareTrace is a set of hooks to run at various stages of the
+// middleware's preflight-request handling, for debugging purposes.
+// Like [net/http/httptrace.ClientTrace], none, some, or all of the hooks
+// may be nil, and a MiddlewareTrace may be installed in a request's
+// context via [WithTrace].
+//
+// Each hook is invoked synchronously, on the goroutine handling the
+// request, immediately after the corresponding decision is made; hooks
+// must therefore return quickly and must not retain any of the slices or
+// the *PreflightInfo passed to them, since the middleware may reuse their
+// backing arrays on subsequent requests.
+type MiddlewareTrace struct {
+	// OriginRejected is called when the request's Origin header fails
+	// validation, with that header's value.
+	OriginRejected func(origin string)
+
+	// MethodRejected is called when the method named in the
+	// Access-Control-Request-Method header is disallowed, with that
+	// header's value.
+	MethodRejected func(method string)
+
+	// HeadersRejected is called when one or more of the header names
+	// listed in the Access-Control-Request-Headers header are
+	// disallowed. requested holds every name in that header, split on
+	// commas, in the order in which they occur in the request; missing
+	// holds the subset of requested that the middleware's configuration
+	// does not allow. Both slices are produced by a single walk over the
+	// header's value (see internal/util's SortedSet.Missing), made only
+	// after validation of that value has failed and only if this hook is
+	// set.
+	HeadersRejected func(requested, missing []string)
+
+	// PrivateNetworkRejected is called when the request carries an
+	// Access-Control-Request-Private-Network header but the middleware's
+	// configuration does not grant private-network access.
+	PrivateNetworkRejected func()
+
+	// PreflightAccepted is called when a preflight request is accepted in
+	// full, i.e. its origin, method, headers, and (if requested) private-
+	// network access all pass validation, with a summary of the accepted
+	// request.
+	PreflightAccepted func(info *PreflightInfo)
+}
+
+// compose returns a MiddlewareTrace whose hooks invoke both t's and old's
+// non-nil hooks of the same name, old's first; either argument may be nil.
+// This mirrors the composition performed by
+// [net/http/httptrace.WithClientTrace] and lets callers layer a new trace
+// on top of one already present in a context without losing the latter's
+// hooks.
+func (t *MiddlewareTrace) compose(old *MiddlewareTrace) *MiddlewareTrace {
+	if old == nil {
+		return t
+	}
+	if t == nil {
+		return old
+	}
+	composed := new(MiddlewareTrace)
+	composed.OriginRejected = composeStringHooks(old.OriginRejected, t.OriginRejected)
+	composed.MethodRejected = composeStringHooks(old.MethodRejected, t.MethodRejected)
+	composed.PrivateNetworkRejected = composeVoidHooks(old.PrivateNetworkRejected, t.PrivateNetworkRejected)
+	composed.HeadersRejected = composeHeadersRejectedHooks(old.HeadersRejected, t.HeadersRejected)
+	composed.PreflightAccepted = composePreflightAcceptedHooks(old.PreflightAccepted, t.PreflightAccepted)
+	return composed
+}
+
+func composeStringHooks(old, new_ func(string)) func(string) {
+	switch {
+	case old == nil:
+		return new_
+	case new_ == nil:
+		return old
+	default:
+		return func(s string) {
+			old(s)
+			new_(s)
+		}
+	}
+}
+
+func composeVoidHooks(old, new_ func()) func() {
+	switch {
+	case old == nil:
+		return new_
+	case new_ == nil:
+		return old
+	default:
+		return func() {
+			old()
+			new_()
+		}
+	}
+}
+
+func composeHeadersRejectedHooks(old, new_ func(requested, missing []string)) func(requested, missing []string) {
+	switch {
+	case old == nil:
+		return new_
+	case new_ == nil:
+		return old
+	default:
+		return func(requested, missing []string) {
+			old(requested, missing)
+			new_(requested, missing)
+		}
+	}
+}
+
+func composePreflightAcceptedHooks(old, new_ func(*PreflightInfo)) func(*PreflightInfo) {
+	switch {
+	case old == nil:
+		return new_
+	case new_ == nil:
+		return old
+	default:
+		return func(info *PreflightInfo) {
+			old(info)
+			new_(info)
+		}
+	}
+}
+
+// traceCtxKey is an unexported type to avoid collisions with context keys
+// defined in other packages.
+type traceCtxKey struct{}
+
+// WithTrace returns a shallow copy of ctx in which trace is installed,
+// composed with any MiddlewareTrace already present in ctx (whose hooks
+// run first). A nil trace is a no-op: WithTrace(ctx, nil) returns ctx
+// unchanged.
+func WithTrace(ctx context.Context, trace *MiddlewareTrace) context.Context {
+	if trace == nil {
+		return ctx
+	}
+	old := ContextMiddlewareTrace(ctx)
+	return context.WithValue(ctx, traceCtxKey{}, trace.compose(old))
+}
+
+// ContextMiddlewareTrace returns the MiddlewareTrace installed in ctx, if
+// any, and nil otherwise. Middleware code calls ContextMiddlewareTrace once
+// per request and then guards each hook invocation with a nil check, so
+// that a request with no trace installed costs only that one nil check per
+// hot-path call site and incurs no heap allocation.
+func ContextMiddlewareTrace(ctx context.Context) *MiddlewareTrace {
+	trace, _ := ctx.Value(traceCtxKey{}).(*MiddlewareTrace)
+	return trace
+}
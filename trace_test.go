@@ -0,0 +1,199 @@
+package cors_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/jub0bs/cors"
+	"github.com/jub0bs/cors/internal/headers"
+	"github.com/jub0bs/cors/internal/util"
+)
+
+func TestContextMiddlewareTraceAbsent(t *testing.T) {
+	if trace := cors.ContextMiddlewareTrace(context.Background()); trace != nil {
+		t.Errorf("ContextMiddlewareTrace(context.Background()) = %v, want nil", trace)
+	}
+}
+
+func TestWithTraceNil(t *testing.T) {
+	ctx := context.Background()
+	if got := cors.WithTrace(ctx, nil); got != ctx {
+		t.Error("WithTrace(ctx, nil) should return ctx unchanged")
+	}
+}
+
+func TestWithTraceComposition(t *testing.T) {
+	var oldCalled, newCalled bool
+	ctx := cors.WithTrace(context.Background(), &cors.MiddlewareTrace{
+		PreflightAccepted: func(*cors.PreflightInfo) { oldCalled = true },
+	})
+	ctx = cors.WithTrace(ctx, &cors.MiddlewareTrace{
+		PreflightAccepted: func(*cors.PreflightInfo) { newCalled = true },
+	})
+	trace := cors.ContextMiddlewareTrace(ctx)
+	if trace == nil || trace.PreflightAccepted == nil {
+		t.Fatal("expected a composed trace with a non-nil PreflightAccepted hook")
+	}
+	trace.PreflightAccepted(&cors.PreflightInfo{Origin: "https://example.com"})
+	if !oldCalled || !newCalled {
+		t.Errorf("oldCalled = %t, newCalled = %t; want both true", oldCalled, newCalled)
+	}
+}
+
+func TestMiddlewareTraceComposeHeadersRejectedAndPrivateNetworkRejected(t *testing.T) {
+	var oldHeaders, newHeaders [][2][]string
+	var pnCalls int
+	ctx := cors.WithTrace(context.Background(), &cors.MiddlewareTrace{
+		HeadersRejected:        func(requested, missing []string) { oldHeaders = append(oldHeaders, [2][]string{requested, missing}) },
+		PrivateNetworkRejected: func() { pnCalls++ },
+	})
+	ctx = cors.WithTrace(ctx, &cors.MiddlewareTrace{
+		HeadersRejected:        func(requested, missing []string) { newHeaders = append(newHeaders, [2][]string{requested, missing}) },
+		PrivateNetworkRejected: func() { pnCalls++ },
+	})
+	trace := cors.ContextMiddlewareTrace(ctx)
+	trace.HeadersRejected([]string{"x-foo"}, []string{"x-foo"})
+	trace.PrivateNetworkRejected()
+	if len(oldHeaders) != 1 || len(newHeaders) != 1 {
+		t.Errorf("HeadersRejected: old called %d times, new called %d times; want 1 each", len(oldHeaders), len(newHeaders))
+	}
+	if pnCalls != 2 {
+		t.Errorf("PrivateNetworkRejected called %d times, want 2", pnCalls)
+	}
+}
+
+// TestConfigHandlePreflightTrace exercises every MiddlewareTrace hook
+// through Config.HandlePreflight, the real decision point each hook is
+// meant to fire from, via both of the documented attachment mechanisms:
+// Config.Trace and a per-request trace installed with WithTrace (which
+// takes precedence over Config.Trace).
+func TestConfigHandlePreflightTrace(t *testing.T) {
+	var cfg cors.Config
+	cfg.AddOrigin("https://example.com")
+	cfg.Methods = util.NewSortedSet("GET")
+	cfg.Headers = util.NewSortedSet("content-type")
+	cfg.PrivateNetwork = false
+
+	cases := []struct {
+		desc   string
+		header http.Header
+	}{
+		{
+			desc: "origin rejected",
+			header: http.Header{
+				headers.Origin: {"https://evil.example.com"},
+				headers.ACRM:   {"GET"},
+			},
+		}, {
+			desc: "method rejected",
+			header: http.Header{
+				headers.Origin: {"https://example.com"},
+				headers.ACRM:   {"DELETE"},
+			},
+		}, {
+			desc: "headers rejected",
+			header: http.Header{
+				headers.Origin: {"https://example.com"},
+				headers.ACRM:   {"GET"},
+				headers.ACRH:   {"content-type,x-bar"},
+			},
+		}, {
+			desc: "private network rejected",
+			header: http.Header{
+				headers.Origin: {"https://example.com"},
+				headers.ACRM:   {"GET"},
+				headers.ACRPN:  {"true"},
+			},
+		}, {
+			desc: "accepted",
+			header: http.Header{
+				headers.Origin: {"https://example.com"},
+				headers.ACRM:   {"GET"},
+				headers.ACRH:   {"content-type"},
+			},
+		},
+	}
+	for _, tc := range cases {
+		f := func(t *testing.T) {
+			var calls []string
+			cfgTrace := &cors.MiddlewareTrace{
+				OriginRejected:         func(string) { calls = append(calls, "OriginRejected") },
+				MethodRejected:         func(string) { calls = append(calls, "MethodRejected") },
+				HeadersRejected:        func(_, _ []string) { calls = append(calls, "HeadersRejected") },
+				PrivateNetworkRejected: func() { calls = append(calls, "PrivateNetworkRejected") },
+				PreflightAccepted:      func(*cors.PreflightInfo) { calls = append(calls, "PreflightAccepted") },
+			}
+			localCfg := cfg
+			localCfg.Trace = cfgTrace
+			req := &http.Request{Header: tc.header}
+			localCfg.HandlePreflight(req)
+			if len(calls) != 1 {
+				t.Fatalf("calls via Config.Trace = %v, want exactly one hook call", calls)
+			}
+
+			// the same decision, reached via a context-installed trace
+			// instead of Config.Trace.
+			calls = nil
+			ctxTrace := &cors.MiddlewareTrace{
+				OriginRejected:         func(string) { calls = append(calls, "OriginRejected") },
+				MethodRejected:         func(string) { calls = append(calls, "MethodRejected") },
+				HeadersRejected:        func(_, _ []string) { calls = append(calls, "HeadersRejected") },
+				PrivateNetworkRejected: func() { calls = append(calls, "PrivateNetworkRejected") },
+				PreflightAccepted:      func(*cors.PreflightInfo) { calls = append(calls, "PreflightAccepted") },
+			}
+			ctx := cors.WithTrace(context.Background(), ctxTrace)
+			req2 := &http.Request{Header: tc.header}
+			req2 = req2.WithContext(ctx)
+			(&cors.Config{Origins: cfg.Origins, Methods: cfg.Methods, Headers: cfg.Headers}).HandlePreflight(req2)
+			if len(calls) != 1 {
+				t.Fatalf("calls via context trace = %v, want exactly one hook call", calls)
+			}
+		}
+		t.Run(tc.desc, f)
+	}
+}
+
+// BenchmarkHandlePreflight_noTrace measures Config.HandlePreflight, the
+// real call site for every MiddlewareTrace hook, with no trace installed;
+// it must show overhead within noise of the no-trace case, since every
+// hook call is guarded by a single nil check that's skipped entirely when
+// ContextMiddlewareTrace and Config.Trace both come back nil.
+func BenchmarkHandlePreflight_noTrace(b *testing.B) {
+	var cfg cors.Config
+	cfg.AddOrigin("https://example.com")
+	cfg.Methods = util.NewSortedSet("GET")
+	cfg.Headers = util.NewSortedSet("content-type")
+	req := &http.Request{Header: http.Header{
+		headers.Origin: {"https://example.com"},
+		headers.ACRM:   {"GET"},
+		headers.ACRH:   {"content-type"},
+	}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cfg.HandlePreflight(req)
+	}
+}
+
+// BenchmarkHandlePreflight_withTrace measures the same call site with a
+// trace installed via Config.Trace, for comparison; the hooks are no-op
+// closures so the benchmark isolates dispatch overhead from hook-body
+// cost.
+func BenchmarkHandlePreflight_withTrace(b *testing.B) {
+	var cfg cors.Config
+	cfg.AddOrigin("https://example.com")
+	cfg.Methods = util.NewSortedSet("GET")
+	cfg.Headers = util.NewSortedSet("content-type")
+	cfg.Trace = &cors.MiddlewareTrace{
+		PreflightAccepted: func(*cors.PreflightInfo) {},
+	}
+	req := &http.Request{Header: http.Header{
+		headers.Origin: {"https://example.com"},
+		headers.ACRM:   {"GET"},
+		headers.ACRH:   {"content-type"},
+	}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cfg.HandlePreflight(req)
+	}
+}